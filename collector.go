@@ -0,0 +1,52 @@
+// Copyright 2022 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SMARTctlCollector implements prometheus.Collector. On every scrape it
+// reads each currently tracked device's cached S.M.A.R.T. data (populated
+// by the background poller in poller.go) and translates it into metrics.
+type SMARTctlCollector struct {
+	logger log.Logger
+}
+
+// NewSMARTctlCollector returns a collector reporting S.M.A.R.T. metrics for
+// every device discovery.go is currently tracking.
+func NewSMARTctlCollector(logger log.Logger) *SMARTctlCollector {
+	return &SMARTctlCollector{logger: logger}
+}
+
+// Describe implements prometheus.Collector. Per-device Descs are sent
+// dynamically from Collect instead of declared here, since the device set
+// changes at runtime as discovery.go adds and evicts devices.
+func (c *SMARTctlCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect implements prometheus.Collector.
+func (c *SMARTctlCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, device := range trackedDevices() {
+		json, err := readData(c.logger, device)
+		if err != nil {
+			level.Warn(c.logger).Log("msg", "Could not collect S.M.A.R.T. data", "device", device, "err", err)
+			continue
+		}
+		collectNVMeLogMetrics(c.logger, ch, device, json)
+		collectSelfTestMetrics(c.logger, ch, device, json)
+		collectVendorAttributes(c.logger, ch, device, json)
+	}
+}
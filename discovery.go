@@ -0,0 +1,179 @@
+// Copyright 2022 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	smartctlDeviceInclude = kingpin.Flag(
+		"smartctl.device-include",
+		"Regular expression of device paths and types to poll; every scanned device matches if unset.",
+	).Default("").String()
+	smartctlDeviceExclude = kingpin.Flag(
+		"smartctl.device-exclude",
+		"Regular expression of device paths and types to skip.",
+	).Default("").String()
+	smartctlRescanInterval = kingpin.Flag(
+		"smartctl.rescan-interval",
+		"Interval at which to rescan for attached devices.",
+	).Default("5m").Duration()
+)
+
+var (
+	deviceIncludeRegexp *regexp.Regexp
+	deviceExcludeRegexp *regexp.Regexp
+	deviceFilterOnce    sync.Once
+
+	knownDevicesMu sync.Mutex
+	knownDevices   = make(map[string]string)
+)
+
+// compileDeviceFilters compiles the --smartctl.device-include/-exclude
+// regexps once. An invalid pattern is logged and treated as unset.
+func compileDeviceFilters(logger log.Logger) {
+	deviceFilterOnce.Do(func() {
+		if *smartctlDeviceInclude != "" {
+			re, err := regexp.Compile(*smartctlDeviceInclude)
+			if err != nil {
+				level.Error(logger).Log("msg", "Invalid --smartctl.device-include regexp", "err", err)
+			} else {
+				deviceIncludeRegexp = re
+			}
+		}
+		if *smartctlDeviceExclude != "" {
+			re, err := regexp.Compile(*smartctlDeviceExclude)
+			if err != nil {
+				level.Error(logger).Log("msg", "Invalid --smartctl.device-exclude regexp", "err", err)
+			} else {
+				deviceExcludeRegexp = re
+			}
+		}
+	})
+}
+
+// deviceMatchesFilters reports whether device should be polled. The device
+// path and its scanned type are matched as independent candidates, not
+// concatenated into one string, so an anchored pattern like "^/dev/sda$"
+// still matches the path on its own.
+func deviceMatchesFilters(device string, deviceType string) bool {
+	if deviceIncludeRegexp != nil && !regexpMatchesAny(deviceIncludeRegexp, device, deviceType) {
+		return false
+	}
+	if deviceExcludeRegexp != nil && regexpMatchesAny(deviceExcludeRegexp, device, deviceType) {
+		return false
+	}
+	return true
+}
+
+// regexpMatchesAny reports whether re matches any of candidates.
+func regexpMatchesAny(re *regexp.Regexp, candidates ...string) bool {
+	for _, candidate := range candidates {
+		if re.MatchString(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunDiscovery scans for attached devices immediately, then rescans every
+// *smartctlRescanInterval so hot-plugged disks are picked up without
+// restarting the exporter. It blocks until logger's program exits, so
+// callers should run it in its own goroutine.
+func RunDiscovery(logger log.Logger) {
+	compileDeviceFilters(logger)
+	rescan(logger)
+	ticker := time.NewTicker(*smartctlRescanInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		rescan(logger)
+	}
+}
+
+// rescan scans for devices, starts polling any that are new and match the
+// filters, and evicts any previously known device that disappeared or no
+// longer matches.
+func rescan(logger log.Logger) {
+	scan := readSMARTctlDevices(logger)
+	discovered := make(map[string]string)
+	for _, d := range scan.Get("devices").Array() {
+		device := d.Get("name").String()
+		if device == "" {
+			continue
+		}
+		deviceType := d.Get("type").String()
+		if !deviceMatchesFilters(device, deviceType) {
+			continue
+		}
+		discovered[device] = deviceType
+	}
+
+	knownDevicesMu.Lock()
+	for device, deviceType := range discovered {
+		if _, ok := knownDevices[device]; !ok {
+			level.Info(logger).Log("msg", "Discovered device", "device", device, "type", deviceType)
+		}
+	}
+	for device := range knownDevices {
+		if _, ok := discovered[device]; !ok {
+			level.Info(logger).Log("msg", "Device no longer present or no longer matches filters, evicting", "device", device)
+			evictDevice(device)
+		}
+	}
+	knownDevices = discovered
+	knownDevicesMu.Unlock()
+
+	StartPolling(logger, discovered)
+}
+
+// evictDevice stops polling device, drops its cached S.M.A.R.T. data, and
+// marks its metric series stale by removing them from the exporter's own
+// device-labeled metric vectors.
+func evictDevice(device string) {
+	StopPolling(device)
+	deleteCacheEntry(device)
+	collectDuration.DeleteLabelValues(device)
+	deviceLastCollect.DeleteLabelValues(device)
+	collectErrors.DeletePartialMatch(prometheus.Labels{"device": device})
+}
+
+// deviceTypeOf returns the scanned type discovery last recorded for device,
+// or "" if discovery hasn't seen it yet (e.g. a scrape for it arrives
+// before the first rescan completes).
+func deviceTypeOf(device string) string {
+	knownDevicesMu.Lock()
+	defer knownDevicesMu.Unlock()
+	return knownDevices[device]
+}
+
+// trackedDevices returns a snapshot of the devices discovery currently
+// considers present and in-filter, for the collector in collector.go to
+// iterate over on each scrape.
+func trackedDevices() []string {
+	knownDevicesMu.Lock()
+	defer knownDevicesMu.Unlock()
+	devices := make([]string, 0, len(knownDevices))
+	for device := range knownDevices {
+		devices = append(devices, device)
+	}
+	return devices
+}
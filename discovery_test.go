@@ -0,0 +1,55 @@
+// Copyright 2022 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestDeviceMatchesFilters(t *testing.T) {
+	cases := []struct {
+		name       string
+		include    string
+		exclude    string
+		device     string
+		deviceType string
+		want       bool
+	}{
+		{"no filters", "", "", "/dev/sda", "sat", true},
+		{"anchored include matches path", "^/dev/sda$", "", "/dev/sda", "sat", true},
+		{"anchored include rejects other path", "^/dev/sda$", "", "/dev/sdb", "sat", false},
+		{"include matches type", "^nvme$", "", "/dev/nvme0", "nvme", true},
+		{"anchored exclude matches path", "", "^/dev/sdb$", "/dev/sdb", "sat", false},
+		{"anchored exclude leaves other path", "", "^/dev/sdb$", "/dev/sda", "sat", true},
+		{"exclude matches type", "", "^nvme$", "/dev/nvme0", "nvme", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			deviceIncludeRegexp = nil
+			deviceExcludeRegexp = nil
+			if c.include != "" {
+				deviceIncludeRegexp = regexp.MustCompile(c.include)
+			}
+			if c.exclude != "" {
+				deviceExcludeRegexp = regexp.MustCompile(c.exclude)
+			}
+			if got := deviceMatchesFilters(c.device, c.deviceType); got != c.want {
+				t.Errorf("deviceMatchesFilters(%q, %q) = %v, want %v", c.device, c.deviceType, got, c.want)
+			}
+		})
+	}
+	deviceIncludeRegexp = nil
+	deviceExcludeRegexp = nil
+}
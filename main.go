@@ -0,0 +1,75 @@
+// Copyright 2022 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/promlog"
+	"github.com/prometheus/common/promlog/flag"
+	"github.com/prometheus/common/version"
+)
+
+var (
+	listenAddress = kingpin.Flag(
+		"web.listen-address",
+		"Address to listen on for web interface and telemetry.",
+	).Default(":9633").String()
+	metricsPath = kingpin.Flag(
+		"web.telemetry-path",
+		"Path under which to expose metrics.",
+	).Default("/metrics").String()
+
+	smartctlPath = kingpin.Flag(
+		"smartctl.path",
+		"The path to the smartctl binary.",
+	).Default("smartctl").String()
+	smartctlInterval = kingpin.Flag(
+		"smartctl.interval",
+		"The interval between smartctl polls of a given device.",
+	).Default("60s").Duration()
+	smartctlFakeData = kingpin.Flag(
+		"smartctl.fake-data",
+		"Read S.M.A.R.T. data from debug/*.json files instead of executing smartctl.",
+	).Default("false").Bool()
+)
+
+func main() {
+	promlogConfig := &promlog.Config{}
+	flag.AddFlags(kingpin.CommandLine, promlogConfig)
+	kingpin.Version(version.Print("smartctl_exporter"))
+	kingpin.HelpFlag.Short('h')
+	kingpin.Parse()
+	logger := promlog.New(promlogConfig)
+
+	level.Info(logger).Log("msg", "Starting smartctl_exporter", "version", version.Info())
+
+	prometheus.MustRegister(NewSMARTctlCollector(logger))
+
+	// Discover attached devices and keep polling them in the background;
+	// the collector above only ever reads what the poller has cached.
+	go RunDiscovery(logger)
+
+	http.Handle(*metricsPath, promhttp.Handler())
+	level.Info(logger).Log("msg", "Listening on address", "address", *listenAddress)
+	if err := http.ListenAndServe(*listenAddress, nil); err != nil {
+		level.Error(logger).Log("msg", "Error starting HTTP server", "err", err)
+		os.Exit(1)
+	}
+}
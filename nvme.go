@@ -0,0 +1,90 @@
+// Copyright 2022 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tidwall/gjson"
+)
+
+var (
+	nvmeCriticalWarning = prometheus.NewDesc(
+		"smartctl_device_nvme_critical_warning",
+		"Critical warning bitmask from the NVMe SMART/health information log page.",
+		[]string{"device"}, nil,
+	)
+	nvmeMediaErrors = prometheus.NewDesc(
+		"smartctl_device_nvme_media_errors",
+		"Number of occurrences where the NVMe controller detected an unrecovered data integrity error.",
+		[]string{"device"}, nil,
+	)
+	nvmeNumErrLogEntries = prometheus.NewDesc(
+		"smartctl_device_nvme_num_err_log_entries",
+		"Number of entries in the NVMe error information log.",
+		[]string{"device"}, nil,
+	)
+	selfTestStatus = prometheus.NewDesc(
+		"smartctl_device_self_test_status",
+		"Result of the most recently completed self-test, 0 meaning the test completed without error.",
+		[]string{"device"}, nil,
+	)
+	lastSelfTestHours = prometheus.NewDesc(
+		"smartctl_device_last_self_test_hours",
+		"Power-on hours at which the most recently completed self-test was run.",
+		[]string{"device"}, nil,
+	)
+)
+
+// collectNVMeLogMetrics reads the NVMe SMART/health information log page
+// from json and sends its counters to ch. It is a no-op for non-NVMe
+// devices, where the health log page is absent from the output.
+func collectNVMeLogMetrics(logger log.Logger, ch chan<- prometheus.Metric, device string, json gjson.Result) {
+	healthLog := json.Get("nvme_smart_health_information_log")
+	if !healthLog.Exists() {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(nvmeCriticalWarning, prometheus.GaugeValue, healthLog.Get("critical_warning").Float(), device)
+	ch <- prometheus.MustNewConstMetric(nvmeMediaErrors, prometheus.GaugeValue, healthLog.Get("media_errors").Float(), device)
+	ch <- prometheus.MustNewConstMetric(nvmeNumErrLogEntries, prometheus.GaugeValue, healthLog.Get("num_err_log_entries").Float(), device)
+}
+
+// collectSelfTestMetrics reads the ATA or NVMe self-test log from json and
+// sends the most recent result to ch. The two log shapes use different
+// field names for the same information, so each is parsed on its own path
+// rather than sharing one set of gjson paths.
+func collectSelfTestMetrics(logger log.Logger, ch chan<- prometheus.Metric, device string, json gjson.Result) {
+	if table := json.Get("ata_smart_self_test_log.standard.table"); table.Exists() {
+		collectLatestSelfTest(ch, device, table.Array(), "status.value", "lifetime_hours")
+		return
+	}
+	if table := json.Get("nvme_self_test_log.table"); table.Exists() {
+		collectLatestSelfTest(ch, device, table.Array(), "self_test_result.value", "power_on_hours")
+		return
+	}
+	level.Debug(logger).Log("msg", "No self-test log entries found", "device", device)
+}
+
+// collectLatestSelfTest sends the status and power-on-hours metrics for the
+// most recent entry in a self-test log table, using statusField and
+// hoursField to account for the ATA/NVMe field name difference.
+func collectLatestSelfTest(ch chan<- prometheus.Metric, device string, entries []gjson.Result, statusField string, hoursField string) {
+	if len(entries) == 0 {
+		return
+	}
+	latest := entries[0]
+	ch <- prometheus.MustNewConstMetric(selfTestStatus, prometheus.GaugeValue, latest.Get(statusField).Float(), device)
+	ch <- prometheus.MustNewConstMetric(lastSelfTestHours, prometheus.GaugeValue, latest.Get(hoursField).Float(), device)
+}
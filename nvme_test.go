@@ -0,0 +1,120 @@
+// Copyright 2022 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/tidwall/gjson"
+)
+
+func TestLogArgsForDeviceType(t *testing.T) {
+	cases := []struct {
+		deviceType string
+		want       []string
+	}{
+		{"", []string{"--log=error", "--log=selftest"}},
+		{"sat", []string{"--log=error", "--log=selftest"}},
+		{"nvme", []string{"--log=error", "--log=selftest", "--log=nvmelog"}},
+		{"nvme,0", []string{"--log=error", "--log=selftest", "--log=nvmelog"}},
+	}
+	for _, c := range cases {
+		got := logArgsForDeviceType(c.deviceType)
+		if len(got) != len(c.want) {
+			t.Fatalf("logArgsForDeviceType(%q) = %v, want %v", c.deviceType, got, c.want)
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Fatalf("logArgsForDeviceType(%q) = %v, want %v", c.deviceType, got, c.want)
+			}
+		}
+	}
+}
+
+func metricValue(t *testing.T, m prometheus.Metric) float64 {
+	t.Helper()
+	var pb dto.Metric
+	if err := m.Write(&pb); err != nil {
+		t.Fatalf("could not write metric: %v", err)
+	}
+	return pb.GetGauge().GetValue()
+}
+
+func TestCollectSelfTestMetricsATA(t *testing.T) {
+	json := gjson.Parse(`{
+		"ata_smart_self_test_log": {
+			"standard": {
+				"table": [
+					{"status": {"value": 0}, "lifetime_hours": 1234}
+				]
+			}
+		}
+	}`)
+	ch := make(chan prometheus.Metric, 10)
+	collectSelfTestMetrics(log.NewNopLogger(), ch, "/dev/sda", json)
+	close(ch)
+
+	var metrics []prometheus.Metric
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("got %d metrics, want 2", len(metrics))
+	}
+	if got := metricValue(t, metrics[0]); got != 0 {
+		t.Errorf("status = %v, want 0", got)
+	}
+	if got := metricValue(t, metrics[1]); got != 1234 {
+		t.Errorf("lifetime_hours = %v, want 1234", got)
+	}
+}
+
+func TestCollectSelfTestMetricsNVMe(t *testing.T) {
+	json := gjson.Parse(`{
+		"nvme_self_test_log": {
+			"table": [
+				{"self_test_result": {"value": 0}, "power_on_hours": 4321}
+			]
+		}
+	}`)
+	ch := make(chan prometheus.Metric, 10)
+	collectSelfTestMetrics(log.NewNopLogger(), ch, "/dev/nvme0", json)
+	close(ch)
+
+	var metrics []prometheus.Metric
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("got %d metrics, want 2", len(metrics))
+	}
+	if got := metricValue(t, metrics[0]); got != 0 {
+		t.Errorf("status = %v, want 0", got)
+	}
+	if got := metricValue(t, metrics[1]); got != 4321 {
+		t.Errorf("power_on_hours = %v, want 4321", got)
+	}
+}
+
+func TestCollectSelfTestMetricsNoLog(t *testing.T) {
+	ch := make(chan prometheus.Metric, 10)
+	collectSelfTestMetrics(log.NewNopLogger(), ch, "/dev/sda", gjson.Parse(`{}`))
+	close(ch)
+	if _, ok := <-ch; ok {
+		t.Fatalf("expected no metrics when no self-test log is present")
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2022 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	collectDuration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "smartctl_exporter_collect_duration_seconds",
+		Help: "Time it took the background poller to run smartctl for a device.",
+	}, []string{"device"})
+	collectErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "smartctl_exporter_collect_errors_total",
+		Help: "Number of failed smartctl polls per device, by reason.",
+	}, []string{"device", "reason"})
+	deviceLastCollect = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "smartctl_exporter_device_last_collect_timestamp_seconds",
+		Help: "Unix timestamp of the last completed poll for a device, successful or not.",
+	}, []string{"device"})
+)
+
+func init() {
+	prometheus.MustRegister(collectDuration, collectErrors, deviceLastCollect)
+}
+
+// pollHandle lets the caller stop polling a single device, used when the
+// device is evicted by the rescan logic in discovery.go.
+type pollHandle struct {
+	stop chan struct{}
+}
+
+var (
+	pollersMu sync.Mutex
+	pollers   = make(map[string]pollHandle)
+)
+
+// StartPolling launches one background goroutine per device that calls
+// smartctl on its own *smartctlInterval schedule and stores the result in
+// jsonCache. Devices already being polled are left untouched. The HTTP
+// handler never calls smartctl itself; it only ever reads jsonCache via
+// readData, so a scrape can't be slowed down or time out waiting on an
+// external process.
+func StartPolling(logger log.Logger, devices map[string]string) {
+	pollersMu.Lock()
+	defer pollersMu.Unlock()
+	for device, deviceType := range devices {
+		if _, ok := pollers[device]; ok {
+			continue
+		}
+		handle := pollHandle{stop: make(chan struct{})}
+		pollers[device] = handle
+		go pollDevice(logger, device, deviceType, handle.stop)
+	}
+}
+
+// StopPolling stops the background goroutine for device, if one is running.
+func StopPolling(device string) {
+	pollersMu.Lock()
+	defer pollersMu.Unlock()
+	if handle, ok := pollers[device]; ok {
+		close(handle.stop)
+		delete(pollers, device)
+	}
+}
+
+// pollDevice runs until stop is closed, collecting device's S.M.A.R.T. data
+// once per *smartctlInterval and recording the outcome in jsonCache.
+func pollDevice(logger log.Logger, device string, deviceType string, stop <-chan struct{}) {
+	collectOnce(logger, device, deviceType)
+	ticker := time.NewTicker(*smartctlInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			collectOnce(logger, device, deviceType)
+		}
+	}
+}
+
+// collectOnce runs smartctl for device, records timing and error metrics,
+// and stores the result in jsonCache.
+func collectOnce(logger log.Logger, device string, deviceType string) {
+	start := time.Now()
+	json, ok := readSMARTctl(logger, device, deviceType)
+	duration := time.Since(start)
+	collectDuration.WithLabelValues(device).Set(duration.Seconds())
+	deviceLastCollect.WithLabelValues(device).Set(float64(time.Now().Unix()))
+
+	var err error
+	if !ok {
+		err = fmt.Errorf("smartctl returned bad data for device %s", device)
+		collectErrors.WithLabelValues(device, "bad_exit_or_json").Inc()
+		level.Warn(logger).Log("msg", "Background poll failed", "device", device, "err", err)
+	}
+	storeCacheResult(device, deviceType, json, err)
+}
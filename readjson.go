@@ -18,6 +18,7 @@ import (
 	"io/ioutil"
 	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
@@ -25,14 +26,18 @@ import (
 	"github.com/tidwall/gjson"
 )
 
-// JSONCache caching json
+// JSONCache caches the most recent smartctl result for a device, as
+// collected by the background poller in poller.go.
 type JSONCache struct {
 	JSON        gjson.Result
+	DeviceType  string
 	LastCollect time.Time
+	LastErr     error
 }
 
 var (
-	jsonCache map[string]JSONCache
+	jsonCache   map[string]JSONCache
+	jsonCacheMu sync.RWMutex
 )
 
 func init() {
@@ -61,9 +66,11 @@ func readFakeSMARTctl(logger log.Logger, device string) gjson.Result {
 }
 
 // Get json from smartctl and parse it
-func readSMARTctl(logger log.Logger, device string) (gjson.Result, bool) {
+func readSMARTctl(logger log.Logger, device string, deviceType string) (gjson.Result, bool) {
 	level.Debug(logger).Log("msg", "Collecting S.M.A.R.T. counters", "device", device)
-	out, err := exec.Command(*smartctlPath, "--json", "--info", "--health", "--attributes", "--tolerance=verypermissive", "--nocheck=standby", "--format=brief", device).Output()
+	args := append([]string{"--json", "--info", "--health", "--attributes", "--tolerance=verypermissive", "--nocheck=standby", "--format=brief"}, logArgsForDeviceType(deviceType)...)
+	args = append(args, device)
+	out, err := exec.Command(*smartctlPath, args...).Output()
 	if err != nil {
 		level.Warn(logger).Log("msg", "S.M.A.R.T. output reading", "err", err)
 	}
@@ -73,6 +80,18 @@ func readSMARTctl(logger log.Logger, device string) (gjson.Result, bool) {
 	return json, rcOk && jsonOk
 }
 
+// logArgsForDeviceType returns the extra --log flags smartctl needs to also
+// return the error log, self-test log, and (for NVMe) the NVMe log page in
+// the JSON output. deviceType is the scan-reported "type" field, e.g. "sat",
+// "nvme", "scsi"; an unrecognized or empty type falls back to the ATA set.
+func logArgsForDeviceType(deviceType string) []string {
+	args := []string{"--log=error", "--log=selftest"}
+	if strings.Contains(deviceType, "nvme") {
+		args = append(args, "--log=nvmelog")
+	}
+	return args
+}
+
 func readSMARTctlDevices(logger log.Logger) gjson.Result {
 	level.Debug(logger).Log("msg", "Scanning for devices")
 	out, err := exec.Command(*smartctlPath, "--json", "--scan").Output()
@@ -87,24 +106,49 @@ func readSMARTctlDevices(logger log.Logger) gjson.Result {
 	return parseJSON(string(out))
 }
 
-// Select json source and parse
+// Select json source and parse. With real data, this is a pure read of
+// whatever the background poller (see poller.go) last collected for device,
+// so a scrape can't be slowed down or time out waiting on an external
+// process. A device the poller hasn't completed its first poll for yet
+// (e.g. the moment after discovery.go first sees it) returns an error.
 func readData(logger log.Logger, device string) (gjson.Result, error) {
 	if *smartctlFakeData {
 		return readFakeSMARTctl(logger, device), nil
 	}
 
+	jsonCacheMu.RLock()
 	cacheValue, cacheOk := jsonCache[device]
-	if !cacheOk || time.Now().After(cacheValue.LastCollect.Add(*smartctlInterval)) {
-		json, ok := readSMARTctl(logger, device)
-		if ok {
-			jsonCache[device] = JSONCache{JSON: json, LastCollect: time.Now()}
-			return jsonCache[device].JSON, nil
-		}
-		return gjson.Parse("{}"), fmt.Errorf("smartctl returned bad data for device %s", device)
+	jsonCacheMu.RUnlock()
+	if !cacheOk {
+		return gjson.Parse("{}"), fmt.Errorf("no S.M.A.R.T. data collected yet for device %s", device)
+	}
+	if cacheValue.LastErr != nil {
+		return cacheValue.JSON, cacheValue.LastErr
 	}
 	return cacheValue.JSON, nil
 }
 
+// storeCacheResult records the outcome of a poll in jsonCache under its
+// mutex. It is called only from the background poller in poller.go.
+func storeCacheResult(device string, deviceType string, json gjson.Result, err error) {
+	jsonCacheMu.Lock()
+	defer jsonCacheMu.Unlock()
+	jsonCache[device] = JSONCache{
+		JSON:        json,
+		DeviceType:  deviceType,
+		LastCollect: time.Now(),
+		LastErr:     err,
+	}
+}
+
+// deleteCacheEntry removes device from jsonCache, used when a device is
+// evicted by the rescan logic in discovery.go.
+func deleteCacheEntry(device string) {
+	jsonCacheMu.Lock()
+	defer jsonCacheMu.Unlock()
+	delete(jsonCache, device)
+}
+
 // Parse smartctl return code
 func resultCodeIsOk(logger log.Logger, SMARTCtlResult int64) bool {
 	result := true
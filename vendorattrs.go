@@ -0,0 +1,182 @@
+// Copyright 2022 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v2"
+)
+
+// VendorAttribute describes how to turn one raw SMART attribute ID into a
+// named Prometheus metric for a given drive vendor. Scale, if non-zero, is
+// multiplied into the raw attribute value before it's emitted; it defaults
+// to 1 (no scaling) when left unset, since that's the zero value YAML
+// produces for an absent field.
+type VendorAttribute struct {
+	ID     int64   `yaml:"id"`
+	Metric string  `yaml:"metric"`
+	Help   string  `yaml:"help"`
+	Scale  float64 `yaml:"scale,omitempty"`
+}
+
+// defaultVendorAttributes are the built-in attribute-ID-to-metric mappings
+// for the vendors whose SMART attribute tables diverge from the generic
+// set, keyed by lowercase vendor name. Users can add vendors or override
+// these via --smartctl.vendor-attributes-file without recompiling.
+var defaultVendorAttributes = map[string][]VendorAttribute{
+	"samsung": {
+		{ID: 231, Metric: "smartctl_device_ssd_life_left_ratio", Help: "SSD endurance remaining, 1.0 meaning new, 0.0 meaning the rated write endurance has been used up.", Scale: 0.01},
+		{ID: 241, Metric: "smartctl_device_total_lbas_written", Help: "Total number of logical blocks written."},
+		{ID: 242, Metric: "smartctl_device_total_lbas_read", Help: "Total number of logical blocks read."},
+	},
+	"crucial/micron": {
+		{ID: 202, Metric: "smartctl_device_ssd_life_left_ratio", Help: "SSD endurance remaining, 1.0 meaning new, 0.0 meaning the rated write endurance has been used up.", Scale: 0.01},
+		{ID: 247, Metric: "smartctl_device_total_lbas_written", Help: "Total number of logical blocks written."},
+		{ID: 248, Metric: "smartctl_device_total_lbas_read", Help: "Total number of logical blocks read."},
+	},
+	"wdc": {
+		{ID: 233, Metric: "smartctl_device_media_wearout_indicator", Help: "Media wearout indicator, decreasing from 100 to 1 as the SSD wears."},
+		{ID: 241, Metric: "smartctl_device_total_lbas_written", Help: "Total number of logical blocks written."},
+		{ID: 242, Metric: "smartctl_device_total_lbas_read", Help: "Total number of logical blocks read."},
+	},
+	"seagate": {
+		{ID: 231, Metric: "smartctl_device_ssd_life_left_ratio", Help: "SSD endurance remaining, 1.0 meaning new, 0.0 meaning the rated write endurance has been used up.", Scale: 0.01},
+		{ID: 241, Metric: "smartctl_device_total_lbas_written", Help: "Total number of logical blocks written."},
+	},
+	"toshiba": {
+		{ID: 233, Metric: "smartctl_device_wear_leveling_count", Help: "Wear leveling count."},
+		{ID: 241, Metric: "smartctl_device_total_lbas_written", Help: "Total number of logical blocks written."},
+	},
+	"intel": {
+		{ID: 233, Metric: "smartctl_device_media_wearout_indicator", Help: "Media wearout indicator, decreasing from 100 to 1 as the SSD wears."},
+		{ID: 241, Metric: "smartctl_device_total_lbas_written", Help: "Total number of logical blocks written."},
+	},
+}
+
+var smartctlVendorAttributesFile = kingpin.Flag(
+	"smartctl.vendor-attributes-file",
+	"Optional YAML file of vendor name to attribute ID to metric mappings, merged over the built-in table.",
+).Default("").String()
+
+var (
+	vendorAttributesOnce sync.Once
+	vendorAttributes     map[string][]VendorAttribute
+
+	vendorDescsMu sync.Mutex
+	vendorDescs   = make(map[string]*prometheus.Desc)
+)
+
+// loadVendorAttributes merges defaultVendorAttributes with any overrides
+// read from *smartctlVendorAttributesFile, the first time it's called.
+// Override vendor names are lowercased to match the lookup in vendorKey.
+func loadVendorAttributes(logger log.Logger) map[string][]VendorAttribute {
+	vendorAttributesOnce.Do(func() {
+		merged := make(map[string][]VendorAttribute, len(defaultVendorAttributes))
+		for vendor, attrs := range defaultVendorAttributes {
+			merged[vendor] = attrs
+		}
+		if *smartctlVendorAttributesFile != "" {
+			data, err := ioutil.ReadFile(*smartctlVendorAttributesFile)
+			if err != nil {
+				level.Error(logger).Log("msg", "Could not read vendor attributes file", "path", *smartctlVendorAttributesFile, "err", err)
+			} else {
+				var overrides map[string][]VendorAttribute
+				if err := yaml.Unmarshal(data, &overrides); err != nil {
+					level.Error(logger).Log("msg", "Could not parse vendor attributes file", "path", *smartctlVendorAttributesFile, "err", err)
+				} else {
+					for vendor, attrs := range overrides {
+						merged[strings.ToLower(vendor)] = attrs
+					}
+				}
+			}
+		}
+		vendorAttributes = merged
+	})
+	return vendorAttributes
+}
+
+// vendorKey picks the vendor attribute table to use for a device, matching
+// the smartctl-reported model_family (preferred) or model_name against the
+// known vendor names.
+func vendorKey(json gjson.Result) (string, bool) {
+	family := strings.ToLower(json.Get("model_family").String())
+	name := strings.ToLower(json.Get("model_name").String())
+	for vendor := range vendorAttributes {
+		if strings.Contains(family, vendor) {
+			return vendor, true
+		}
+	}
+	for vendor := range vendorAttributes {
+		if strings.Contains(name, vendor) {
+			return vendor, true
+		}
+	}
+	return "", false
+}
+
+// descForMetric returns the Desc for a vendor-mapped metric name, creating
+// and caching it on first use. Vendor metric names come from config rather
+// than a fixed set, so they can't be declared as package-level vars up
+// front the way the NVMe metrics in nvme.go are.
+func descForMetric(name string, help string) *prometheus.Desc {
+	vendorDescsMu.Lock()
+	defer vendorDescsMu.Unlock()
+	desc, ok := vendorDescs[name]
+	if !ok {
+		desc = prometheus.NewDesc(name, help, []string{"device"}, nil)
+		vendorDescs[name] = desc
+	}
+	return desc
+}
+
+// collectVendorAttributes decodes json's SMART attribute table for device
+// using the vendor-specific ID mapping and sends the result to ch. Devices
+// whose vendor isn't recognized, or whose table lacks a mapped attribute
+// ID, are silently skipped.
+func collectVendorAttributes(logger log.Logger, ch chan<- prometheus.Metric, device string, json gjson.Result) {
+	attrs := loadVendorAttributes(logger)
+	vendor, ok := vendorKey(json)
+	if !ok {
+		return
+	}
+
+	table := json.Get("ata_smart_attributes.table")
+	if !table.Exists() {
+		return
+	}
+	raw := make(map[int64]float64)
+	for _, entry := range table.Array() {
+		raw[entry.Get("id").Int()] = entry.Get("raw.value").Float()
+	}
+
+	for _, mapping := range attrs[vendor] {
+		value, ok := raw[mapping.ID]
+		if !ok {
+			continue
+		}
+		scale := mapping.Scale
+		if scale == 0 {
+			scale = 1
+		}
+		ch <- prometheus.MustNewConstMetric(descForMetric(mapping.Metric, mapping.Help), prometheus.GaugeValue, value*scale, device)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2022 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tidwall/gjson"
+)
+
+func TestVendorKey(t *testing.T) {
+	loadVendorAttributes(log.NewNopLogger())
+
+	cases := []struct {
+		name       string
+		json       string
+		wantVendor string
+		wantOk     bool
+	}{
+		{"model family", `{"model_family": "Samsung based SSDs"}`, "samsung", true},
+		{"model name fallback", `{"model_name": "WDC WD40EFRX-68N32N0"}`, "wdc", true},
+		{"unknown vendor", `{"model_family": "Some Other Drive"}`, "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			vendor, ok := vendorKey(gjson.Parse(c.json))
+			if ok != c.wantOk || vendor != c.wantVendor {
+				t.Errorf("vendorKey(%s) = (%q, %v), want (%q, %v)", c.json, vendor, ok, c.wantVendor, c.wantOk)
+			}
+		})
+	}
+}
+
+func TestCollectVendorAttributes(t *testing.T) {
+	json := gjson.Parse(`{
+		"model_family": "Samsung based SSDs",
+		"ata_smart_attributes": {
+			"table": [
+				{"id": 231, "raw": {"value": 87}},
+				{"id": 241, "raw": {"value": 55834574848}}
+			]
+		}
+	}`)
+	ch := make(chan prometheus.Metric, 10)
+	collectVendorAttributes(log.NewNopLogger(), ch, "/dev/sda", json)
+	close(ch)
+
+	got := map[string]float64{}
+	for m := range ch {
+		got[m.Desc().String()] = metricValue(t, m)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d metrics, want 2: %v", len(got), got)
+	}
+	for desc, value := range got {
+		switch {
+		case strings.Contains(desc, "smartctl_device_ssd_life_left_ratio"):
+			if value != 0.87 {
+				t.Errorf("ssd_life_left_ratio = %v, want 0.87 (raw attribute 231 is a 0-100 percentage)", value)
+			}
+		case strings.Contains(desc, "smartctl_device_total_lbas_written"):
+			if value != 55834574848 {
+				t.Errorf("total_lbas_written = %v, want 55834574848", value)
+			}
+		default:
+			t.Errorf("unexpected metric %s", desc)
+		}
+	}
+}
+
+func TestCollectVendorAttributesUnknownVendor(t *testing.T) {
+	ch := make(chan prometheus.Metric, 10)
+	collectVendorAttributes(log.NewNopLogger(), ch, "/dev/sda", gjson.Parse(`{"model_family": "Unknown Drive Co"}`))
+	close(ch)
+	if _, ok := <-ch; ok {
+		t.Fatalf("expected no metrics for an unrecognized vendor")
+	}
+}